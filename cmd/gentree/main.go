@@ -0,0 +1,55 @@
+/**
+ * Command gentree trains a FAST decision-tree fast path from a labeled
+ * patch set and writes it as a Go source file, for baking a pre-generated
+ * table into the fast package (see fast.Detector.Compile).
+ */
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/lafin/fast"
+)
+
+func main() {
+	var patchesPath = flag.String("patches", "", "path to a JSON file holding a []fast.LabeledPatch training set (required)")
+	var variant = flag.String("variant", "fast9", "variant the patches were labeled for: fast9 or fast12")
+	var out = flag.String("out", "tree_generated.go", "output Go source file")
+	var varName = flag.String("var", "generatedTree", "name of the generated tree variable")
+	flag.Parse()
+
+	if *patchesPath == "" {
+		log.Fatal("gentree: -patches is required; there is no pre-generated tree to train from")
+	}
+
+	data, err := os.ReadFile(*patchesPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var patches []fast.LabeledPatch
+	if err := json.Unmarshal(data, &patches); err != nil {
+		log.Fatal(err)
+	}
+
+	var v = fast.FAST9
+	if *variant == "fast12" {
+		v = fast.FAST12
+	}
+
+	var d = fast.Detector{Variant: v}
+	d.Compile(patches)
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := d.EncodeTree(f, *varName); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -22,31 +22,76 @@
 // Package fast - implementation of the algorithm of the same name
 package fast
 
-// FindCorners - Finds corners coordinates on the graysacaled image.
-func FindCorners(pixels map[int]int, width, height, threshold int) []int {
+import "image"
+
+// Corner - a detected corner, its position, a score describing how robust
+// the detection is, and an orientation (populated only by
+// FindOrientedCorners, zero otherwise).
+type Corner struct {
+	X, Y  int
+	Score int
+	Theta float32
+}
+
+// Variant selects how many contiguous circle pixels the segment test
+// requires, mirroring the standard FAST-9 and FAST-12 corner definitions.
+type Variant int
+
+const (
+	// FAST9 requires 9 contiguous circle pixels to be all brighter or all
+	// darker than the candidate pixel. This is the variant FindCorners has
+	// always used.
+	FAST9 Variant = iota
+	// FAST12 requires 12 contiguous circle pixels, the stricter segment
+	// test from the original paper.
+	FAST12
+)
+
+// Detector runs the segment test for a configurable Variant, where
+// FindCorners and FindCornersWithScore are hard-coded to FAST9. Once
+// Compile has been called, FindCorners walks the compiled decision tree
+// instead of the arithmetic segment test.
+type Detector struct {
+	Variant Variant
+
+	tree []treeNode
+}
+
+/**
+ * Returns the segment length n for d's Variant.
+ */
+func (d Detector) n() int {
+	if d.Variant == FAST12 {
+		return 12
+	}
+	return 9
+}
+
+// FindCorners - Finds corners coordinates on the grayscaled image using d's
+// Variant, returned as a flat (x, y, x, y, ...) slice.
+func (d Detector) FindCorners(pixels map[int]int, width, height, threshold int) []int {
 	var circleOffsets = getCircleOffsets(width)
 	var circlePixels [16]int
 	var corners []int
+	var n = d.n()
 
-	// When looping through the image pixels, skips the first three lines from
-	// the image boundaries to constrain the surrounding circle inside the image
-	// area.
 	for i := 3; i < height-3; i++ {
 		for j := 3; j < width-3; j++ {
 			var w = i*width + j
 			var p = pixels[w]
 
-			// Loops the circle offsets to read the pixel value for the sixteen
-			// surrounding pixels.
 			for k := 0; k < 16; k++ {
 				circlePixels[k] = pixels[w+circleOffsets[k]]
 			}
 
-			if isCorner(p, circlePixels, threshold) {
-				// The pixel p is classified as a corner, as optimization increment j
-				// by the circle radius 3 to skip the neighbor pixels inside the
-				// surrounding circle. This can be removed without compromising the
-				// result.
+			var corner bool
+			if d.tree != nil {
+				corner = d.isCornerTree(p, circlePixels, threshold)
+			} else {
+				corner = isCornerN(p, circlePixels, threshold, n)
+			}
+
+			if corner {
 				corners = append(corners, j, i)
 				j += 3
 			}
@@ -56,12 +101,184 @@ func FindCorners(pixels map[int]int, width, height, threshold int) []int {
 	return corners
 }
 
+// FindCorners - Finds corners coordinates on the graysacaled image.
+//
+// Deprecated: pixels as a map[int]int is slow, a hash lookup per pixel for
+// each of the 16 circle offsets, and awkward for callers. Use
+// FindCornersGray with a standard *image.Gray instead; this is now a thin
+// adapter over Detector{Variant: FAST9} kept for one release.
+func FindCorners(pixels map[int]int, width, height, threshold int) []int {
+	return Detector{Variant: FAST9}.FindCorners(pixels, width, height, threshold)
+}
+
+// FindCornersGray - Finds corners on img and returns them as Corners, with
+// scores computed the same way as FindCornersWithScore. Operating directly
+// on img.Pix with img.Stride avoids the per-pixel map lookups FindCorners
+// pays for, and lets callers pass sub-images via img.SubImage.
+func FindCornersGray(img *image.Gray, threshold int) []Corner {
+	return Detector{Variant: FAST9}.FindCornersGray(img, threshold)
+}
+
+// FindCornersGray - Finds corners on img and returns them as Corners, using
+// d's Variant, or d's compiled tree (see Compile) once one has been set.
+func (d Detector) FindCornersGray(img *image.Gray, threshold int) []Corner {
+	var bounds = img.Bounds()
+	var read = func(offset int) int { return int(img.Pix[offset]) }
+	var cornerTest, score = d.segmentTest()
+	return scanCorners(read, img.Stride, bounds.Dx(), bounds.Dy(), threshold, cornerTest, score)
+}
+
+// FindCornersWithScore - Finds corners coordinates on the grayscaled image,
+// same as FindCorners, but returns a Corner per detection carrying a score:
+// the largest threshold for which the pixel still qualifies as a corner,
+// found by binary-searching between threshold and 255 using isCornerN.
+func FindCornersWithScore(pixels map[int]int, width, height, threshold int) []Corner {
+	return Detector{Variant: FAST9}.FindCornersWithScore(pixels, width, height, threshold)
+}
+
+// FindCornersWithScore - Finds corners coordinates on the grayscaled image
+// using d's Variant, or d's compiled tree (see Compile) once one has been
+// set, same as the package-level FindCornersWithScore but able to run
+// FAST12 or a compiled tree.
+func (d Detector) FindCornersWithScore(pixels map[int]int, width, height, threshold int) []Corner {
+	var read = func(offset int) int { return pixels[offset] }
+	var cornerTest, score = d.segmentTest()
+	return scanCorners(read, width, width, height, threshold, cornerTest, score)
+}
+
+/**
+ * Returns the corner test and score functions d's FindCornersGray and
+ * FindCornersWithScore should scan with: the compiled tree if Compile has
+ * been called, otherwise the arithmetic segment test for d's Variant. The
+ * score is always computed arithmetically (via cornerScore), even when the
+ * tree decides acceptance, since the tree itself has no notion of score.
+ */
+func (d Detector) segmentTest() (func(p int, circlePixels [16]int, threshold int) bool, func(p int, circlePixels [16]int, threshold int) int) {
+	var n = d.n()
+	var score = func(p int, circlePixels [16]int, threshold int) int { return cornerScore(p, circlePixels, threshold, n) }
+
+	if d.tree != nil {
+		return d.isCornerTree, score
+	}
+
+	var cornerTest = func(p int, circlePixels [16]int, threshold int) bool { return isCornerN(p, circlePixels, threshold, n) }
+	return cornerTest, score
+}
+
+/**
+ * Shared core of FindCornersGray and FindCornersWithScore: walks a
+ * width x height grayscale buffer of the given stride, reading one pixel
+ * at a time via read, and returns every pixel satisfying cornerTest as a
+ * Corner with a score from score.
+ */
+func scanCorners(read func(offset int) int, stride, width, height, threshold int, cornerTest func(p int, circlePixels [16]int, threshold int) bool, score func(p int, circlePixels [16]int, threshold int) int) []Corner {
+	var circleOffsets = getCircleOffsets(stride)
+	var circlePixels [16]int
+	var corners []Corner
+
+	for i := 3; i < height-3; i++ {
+		for j := 3; j < width-3; j++ {
+			var w = i*stride + j
+			var p = read(w)
+
+			for k := 0; k < 16; k++ {
+				circlePixels[k] = read(w + circleOffsets[k])
+			}
+
+			if cornerTest(p, circlePixels, threshold) {
+				corners = append(corners, Corner{X: j, Y: i, Score: score(p, circlePixels, threshold)})
+				j += 3
+			}
+		}
+	}
+
+	return corners
+}
+
+/**
+ * Finds the largest threshold between threshold and 255 for which the
+ * pixel still qualifies as a corner for segment length n, by
+ * binary-searching isCornerN. isCornerN is monotonic in the threshold
+ * (raising it only makes the test stricter), which is what makes the
+ * binary search valid.
+ */
+func cornerScore(p int, circlePixels [16]int, threshold, n int) int {
+	var lo, hi = threshold, 255
+
+	for lo < hi {
+		var mid = lo + (hi-lo+1)/2
+
+		if isCornerN(p, circlePixels, mid, n) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	return lo
+}
+
+// SuppressNonMaximum - discards each corner that has another corner within
+// a Chebyshev distance of radius with a strictly greater score, breaking
+// ties by lexicographic (y, x) order. Useful to collapse the clusters of
+// duplicate detections FAST tends to produce around each true corner.
+func SuppressNonMaximum(corners []Corner, radius int) []Corner {
+	var result []Corner
+
+	for i, c := range corners {
+		var suppressed = false
+
+		for j, other := range corners {
+			if i == j {
+				continue
+			}
+			if abs(other.X-c.X) > radius || abs(other.Y-c.Y) > radius {
+				continue
+			}
+			if beats(other, c) {
+				suppressed = true
+				break
+			}
+		}
+
+		if !suppressed {
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
+
+/**
+ * Reports whether a should survive non-maximum suppression over b: a has a
+ * strictly greater score, or an equal score and a lexicographically smaller
+ * (y, x) position.
+ */
+func beats(a, b Corner) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.X < b.X
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
 /**
  * Checks if the circle pixel is within the corner of the candidate pixel p
- * by a threshold.
+ * by a threshold, for a configurable segment length n. Every exported
+ * entry point uses this (with n=9 for the FAST9-only ones, or d.n() behind
+ * Detector), so the isTriviallyExcludedN relaxation below always applies.
  */
-func isCorner(p int, circlePixels [16]int, threshold int) bool {
-	if isTriviallyExcluded(circlePixels, p, threshold) {
+func isCornerN(p int, circlePixels [16]int, threshold, n int) bool {
+	if isTriviallyExcludedN(circlePixels, p, threshold, n) {
 		return false
 	}
 
@@ -69,7 +286,7 @@ func isCorner(p int, circlePixels [16]int, threshold int) bool {
 		var darker = true
 		var brighter = true
 
-		for y := 0; y < 9; y++ {
+		for y := 0; y < n; y++ {
 			var circlePixel = circlePixels[(x+y)&15]
 
 			if !isBrighter(p, circlePixel, threshold) {
@@ -95,12 +312,27 @@ func isCorner(p int, circlePixels [16]int, threshold int) bool {
 	return false
 }
 
+/**
+ * Fast check to test if the candidate pixel is a trivially excluded value,
+ * aware of the segment length n. The 3-of-4 cardinal test only proves a
+ * candidate can't satisfy a segment as short as n when n >= 12 (FAST12);
+ * for shorter segments (FAST9) it is invalid and is skipped, leaving the
+ * full segment test in isCornerN to decide.
+ */
+func isTriviallyExcludedN(circlePixels [16]int, p, threshold, n int) bool {
+	if n < 12 {
+		return false
+	}
+	return isTriviallyExcluded(circlePixels, p, threshold)
+}
+
 /**
  * Fast check to test if the candidate pixel is a trivially excluded value.
  * In order to be a corner, the candidate pixel value should be darker or
  * brighter than 9-12 surrounding pixels, when at least three of the top,
  * bottom, left and right pixels are brighter or darker it can be
- * automatically excluded improving the performance.
+ * automatically excluded improving the performance. Only valid for n >= 12;
+ * see isTriviallyExcludedN.
  */
 func isTriviallyExcluded(circlePixels [16]int, p int, threshold int) bool {
 	var count = 0
@@ -0,0 +1,49 @@
+package fast
+
+import "testing"
+
+func TestSuppressNonMaximumScoreWins(t *testing.T) {
+	corners := []Corner{
+		{X: 10, Y: 10, Score: 30},
+		{X: 11, Y: 10, Score: 90},
+	}
+
+	got := SuppressNonMaximum(corners, 3)
+
+	if len(got) != 1 {
+		t.Fatalf("expected 1 surviving corner, got %d: %v", len(got), got)
+	}
+	if got[0].Score != 90 {
+		t.Fatalf("expected the higher-scoring corner to survive, got %v", got[0])
+	}
+}
+
+func TestSuppressNonMaximumTieBreaksByPosition(t *testing.T) {
+	corners := []Corner{
+		{X: 5, Y: 5, Score: 50},
+		{X: 4, Y: 5, Score: 50},
+		{X: 5, Y: 4, Score: 50},
+	}
+
+	got := SuppressNonMaximum(corners, 3)
+
+	if len(got) != 1 {
+		t.Fatalf("expected 1 surviving corner, got %d: %v", len(got), got)
+	}
+	if got[0].X != 5 || got[0].Y != 4 {
+		t.Fatalf("expected the lexicographically smallest (y, x) corner to survive, got %v", got[0])
+	}
+}
+
+func TestSuppressNonMaximumKeepsCornersOutsideRadius(t *testing.T) {
+	corners := []Corner{
+		{X: 0, Y: 0, Score: 10},
+		{X: 20, Y: 20, Score: 90},
+	}
+
+	got := SuppressNonMaximum(corners, 3)
+
+	if len(got) != 2 {
+		t.Fatalf("expected both corners to survive (out of radius), got %d: %v", len(got), got)
+	}
+}
@@ -0,0 +1,82 @@
+package fast
+
+import (
+	"image"
+	"math"
+)
+
+// orientationRadius is the patch radius used by the intensity centroid
+// method in FindOrientedCorners, matching the ORB paper's choice of r=15.
+const orientationRadius = 15
+
+// circularMaskRanges precomputes, for each row offset dy in
+// [-orientationRadius, orientationRadius], the largest dx such that
+// (dx, dy) falls inside the orientationRadius circle, so
+// intensityCentroidAngle doesn't re-derive it per pixel.
+var circularMaskRanges = computeCircularMaskRanges(orientationRadius)
+
+func computeCircularMaskRanges(radius int) []int {
+	var ranges = make([]int, 2*radius+1)
+
+	for dy := -radius; dy <= radius; dy++ {
+		var maxDx = 0
+		for dx := radius; dx >= 0; dx-- {
+			if dx*dx+dy*dy <= radius*radius {
+				maxDx = dx
+				break
+			}
+		}
+		ranges[dy+radius] = maxDx
+	}
+
+	return ranges
+}
+
+// FindOrientedCorners finds corners on img the same way FindCornersGray
+// does, and additionally computes each corner's orientation with the
+// intensity centroid method, as required by ORB-style descriptors such as
+// rBRIEF.
+func FindOrientedCorners(img *image.Gray, threshold int) []Corner {
+	var corners = FindCornersGray(img, threshold)
+	var bounds = img.Bounds()
+
+	for i := range corners {
+		var x = bounds.Min.X + corners[i].X
+		var y = bounds.Min.Y + corners[i].Y
+		corners[i].Theta = intensityCentroidAngle(img, x, y)
+	}
+
+	return corners
+}
+
+/**
+ * Computes a corner's orientation using the intensity centroid method:
+ * accumulates the moments m10 = sum(x*I(x,y)) and m01 = sum(y*I(x,y)) over
+ * a circular patch of radius orientationRadius around (x, y), x and y
+ * relative to the corner, then returns atan2(m01, m10).
+ */
+func intensityCentroidAngle(img *image.Gray, x, y int) float32 {
+	var bounds = img.Bounds()
+	var m10, m01 int
+
+	for dy := -orientationRadius; dy <= orientationRadius; dy++ {
+		var row = y + dy
+		if row < bounds.Min.Y || row >= bounds.Max.Y {
+			continue
+		}
+
+		var maxDx = circularMaskRanges[dy+orientationRadius]
+		for dx := -maxDx; dx <= maxDx; dx++ {
+			var col = x + dx
+			if col < bounds.Min.X || col >= bounds.Max.X {
+				continue
+			}
+
+			var intensity = int(img.Pix[img.PixOffset(col, row)])
+			m10 += dx * intensity
+			m01 += dy * intensity
+		}
+	}
+
+	return float32(math.Atan2(float64(m01), float64(m10)))
+}
@@ -0,0 +1,102 @@
+package fast
+
+import "image"
+
+// ScaledCorner is a corner detected at one level of an image pyramid,
+// remapped back to level-0 image coordinates.
+type ScaledCorner struct {
+	X, Y  int
+	Level int
+	Scale float32
+	Score int
+}
+
+// FindCornersPyramid runs FAST at levels successive scales of img, built by
+// downsampling by scaleFactor at each level, and remaps every corner back
+// to level-0 coordinates. Non-maximum suppression is applied within each
+// level and then again across levels, so a corner that shows up at several
+// adjacent scales is reported once, at its highest-scoring level.
+func FindCornersPyramid(img *image.Gray, threshold int, levels int, scaleFactor float64) []ScaledCorner {
+	var scaled []ScaledCorner
+	var level = img
+	var scale = 1.0
+
+	for l := 0; l < levels; l++ {
+		var corners = SuppressNonMaximum(FindCornersGray(level, threshold), 3)
+
+		for _, c := range corners {
+			scaled = append(scaled, ScaledCorner{
+				X:     int(float64(c.X)*scale + 0.5),
+				Y:     int(float64(c.Y)*scale + 0.5),
+				Level: l,
+				Scale: float32(scale),
+				Score: c.Score,
+			})
+		}
+
+		if l == levels-1 {
+			break
+		}
+
+		level = downsample(level, scaleFactor)
+		scale *= scaleFactor
+	}
+
+	return suppressAcrossScales(scaled, 3)
+}
+
+/**
+ * Builds a new *image.Gray scaled down by factor using nearest-neighbour
+ * sampling, the simplest pyramid level that still keeps FAST's candidate
+ * pixels well-defined.
+ */
+func downsample(img *image.Gray, factor float64) *image.Gray {
+	var bounds = img.Bounds()
+	var width = int(float64(bounds.Dx()) / factor)
+	var height = int(float64(bounds.Dy()) / factor)
+	var out = image.NewGray(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var srcX = bounds.Min.X + int(float64(x)*factor)
+			var srcY = bounds.Min.Y + int(float64(y)*factor)
+			out.Pix[out.PixOffset(x, y)] = img.Pix[img.PixOffset(srcX, srcY)]
+		}
+	}
+
+	return out
+}
+
+/**
+ * Applies the same non-maximum suppression rule as SuppressNonMaximum
+ * across pyramid levels, comparing remapped level-0 coordinates, so
+ * duplicate detections of the same physical corner at adjacent scales
+ * collapse to the entry that actually wins (not just whichever of them
+ * happens to come first in corners).
+ */
+func suppressAcrossScales(corners []ScaledCorner, radius int) []ScaledCorner {
+	var result []ScaledCorner
+
+	for i, c := range corners {
+		var suppressed = false
+
+		for j, other := range corners {
+			if i == j {
+				continue
+			}
+			if abs(other.X-c.X) > radius || abs(other.Y-c.Y) > radius {
+				continue
+			}
+			if beats(Corner{X: other.X, Y: other.Y, Score: other.Score}, Corner{X: c.X, Y: c.Y, Score: c.Score}) {
+				suppressed = true
+				break
+			}
+		}
+
+		if !suppressed {
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,292 @@
+package fast
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// LabeledPatch is a single training example for Detector.Compile: the
+// sixteen circle intensities and center value observed around a candidate
+// pixel, the threshold it was classified at, and whether it is a corner.
+type LabeledPatch struct {
+	P            int
+	CirclePixels [16]int
+	Threshold    int
+	IsCorner     bool
+}
+
+// treeNode is one node of a compiled decision tree, following Rosten &
+// Drummond's ID3-trained segment test: querying circle position Pos
+// classifies the candidate as brighter, darker, or similar to the center
+// pixel (by more than the detection threshold), then branches to the
+// matching child. A negative child index is a terminal leaf.
+type treeNode struct {
+	Pos                       uint8
+	Brighter, Darker, Similar int16
+}
+
+const (
+	leafNotCorner int16 = -1
+	leafCorner    int16 = -2
+)
+
+// Compile builds the decision-tree fast path used by FindCorners in place
+// of the arithmetic segment test, training a tree from patches with the
+// classic ID3 algorithm: at each node it picks the circle position that
+// maximizes information gain over the training set. There is no
+// pre-generated table to fall back on, so patches must be real labeled
+// data (see cmd/gentree for baking the result into the package); Compile
+// panics if given none.
+func (d *Detector) Compile(patches []LabeledPatch) {
+	if len(patches) == 0 {
+		panic("fast: Compile requires at least one LabeledPatch of real training data")
+	}
+	d.tree = trainTree(patches, allPositions())
+}
+
+/**
+ * Walks d's compiled tree for the given candidate pixel and its circle,
+ * stopping at a leaf. threshold is applied at walk time rather than baked
+ * into the tree, but the tree's splits and leaf labels were chosen by ID3
+ * against patches labeled at their own training threshold, so the tree is
+ * only valid for thresholds close to that training threshold; walking it
+ * far from there evaluates branch conditions it was never optimized for.
+ */
+func (d Detector) isCornerTree(p int, circlePixels [16]int, threshold int) bool {
+	var idx = int16(0)
+
+	for idx >= 0 {
+		var node = d.tree[idx]
+		var delta = circlePixels[node.Pos] - p
+
+		switch {
+		case delta > threshold:
+			idx = node.Brighter
+		case delta < -threshold:
+			idx = node.Darker
+		default:
+			idx = node.Similar
+		}
+	}
+
+	return idx == leafCorner
+}
+
+type splitClass int
+
+const (
+	classBrighter splitClass = iota
+	classDarker
+	classSimilar
+)
+
+/**
+ * Classifies patch's circle position pos relative to its center pixel and
+ * threshold, the same three-way test isCornerTree branches on.
+ */
+func classify(patch LabeledPatch, pos int) splitClass {
+	var delta = patch.CirclePixels[pos] - patch.P
+
+	switch {
+	case delta > patch.Threshold:
+		return classBrighter
+	case delta < -patch.Threshold:
+		return classDarker
+	default:
+		return classSimilar
+	}
+}
+
+/**
+ * Trains a decision tree over patches via ID3, picking at each node the
+ * circle position among positions that maximizes information gain.
+ */
+func trainTree(patches []LabeledPatch, positions []int) []treeNode {
+	var tree []treeNode
+	buildTreeNode(&tree, patches, positions)
+	return tree
+}
+
+/**
+ * Recursively builds one node of tree for patches, appending to tree and
+ * returning the node's index (or a leaf index if patches is pure or no
+ * informative position remains).
+ */
+func buildTreeNode(tree *[]treeNode, patches []LabeledPatch, positions []int) int16 {
+	if pure, label := isPure(patches); pure {
+		return leafFor(label)
+	}
+	if len(positions) == 0 {
+		return leafFor(majorityLabel(patches))
+	}
+
+	var bestPos = bestSplit(patches, positions)
+	var brighter, darker, similar []LabeledPatch
+
+	for _, patch := range patches {
+		switch classify(patch, bestPos) {
+		case classBrighter:
+			brighter = append(brighter, patch)
+		case classDarker:
+			darker = append(darker, patch)
+		default:
+			similar = append(similar, patch)
+		}
+	}
+
+	var remaining = removePosition(positions, bestPos)
+	var idx = int16(len(*tree))
+	*tree = append(*tree, treeNode{Pos: uint8(bestPos)})
+
+	(*tree)[idx].Brighter = leafOrBuild(tree, brighter, remaining)
+	(*tree)[idx].Darker = leafOrBuild(tree, darker, remaining)
+	(*tree)[idx].Similar = leafOrBuild(tree, similar, remaining)
+
+	return idx
+}
+
+func leafOrBuild(tree *[]treeNode, patches []LabeledPatch, positions []int) int16 {
+	if len(patches) == 0 {
+		return leafNotCorner
+	}
+	return buildTreeNode(tree, patches, positions)
+}
+
+/**
+ * Reports whether every patch shares the same label, and that label.
+ */
+func isPure(patches []LabeledPatch) (bool, bool) {
+	if len(patches) == 0 {
+		return true, false
+	}
+
+	var label = patches[0].IsCorner
+	for _, patch := range patches[1:] {
+		if patch.IsCorner != label {
+			return false, false
+		}
+	}
+
+	return true, label
+}
+
+func majorityLabel(patches []LabeledPatch) bool {
+	var corners = 0
+	for _, patch := range patches {
+		if patch.IsCorner {
+			corners++
+		}
+	}
+	return corners*2 >= len(patches)
+}
+
+func leafFor(isCorner bool) int16 {
+	if isCorner {
+		return leafCorner
+	}
+	return leafNotCorner
+}
+
+/**
+ * Picks the circle position among positions whose brighter/darker/similar
+ * split maximizes information gain over patches.
+ */
+func bestSplit(patches []LabeledPatch, positions []int) int {
+	var best = positions[0]
+	var bestGain = informationGain(patches, best)
+
+	for _, pos := range positions[1:] {
+		var gain = informationGain(patches, pos)
+		if gain > bestGain {
+			best = pos
+			bestGain = gain
+		}
+	}
+
+	return best
+}
+
+func informationGain(patches []LabeledPatch, pos int) float64 {
+	var brighter, darker, similar []LabeledPatch
+
+	for _, patch := range patches {
+		switch classify(patch, pos) {
+		case classBrighter:
+			brighter = append(brighter, patch)
+		case classDarker:
+			darker = append(darker, patch)
+		default:
+			similar = append(similar, patch)
+		}
+	}
+
+	var total = float64(len(patches))
+	var weighted = float64(len(brighter))/total*entropy(brighter) +
+		float64(len(darker))/total*entropy(darker) +
+		float64(len(similar))/total*entropy(similar)
+
+	return entropy(patches) - weighted
+}
+
+func entropy(patches []LabeledPatch) float64 {
+	if len(patches) == 0 {
+		return 0
+	}
+
+	var corners = 0
+	for _, patch := range patches {
+		if patch.IsCorner {
+			corners++
+		}
+	}
+
+	var pCorner = float64(corners) / float64(len(patches))
+	var pNotCorner = 1 - pCorner
+	var h = 0.0
+
+	if pCorner > 0 {
+		h -= pCorner * math.Log2(pCorner)
+	}
+	if pNotCorner > 0 {
+		h -= pNotCorner * math.Log2(pNotCorner)
+	}
+
+	return h
+}
+
+func allPositions() []int {
+	var positions = make([]int, 16)
+	for i := range positions {
+		positions[i] = i
+	}
+	return positions
+}
+
+func removePosition(positions []int, pos int) []int {
+	var remaining = make([]int, 0, len(positions)-1)
+	for _, p := range positions {
+		if p != pos {
+			remaining = append(remaining, p)
+		}
+	}
+	return remaining
+}
+
+// EncodeTree writes d's compiled tree to w as a Go source file declaring a
+// []treeNode literal named varName. Used by cmd/gentree to bake a trained
+// tree into the package as a pre-generated table.
+func (d Detector) EncodeTree(w io.Writer, varName string) error {
+	if _, err := fmt.Fprintf(w, "package fast\n\nvar %s = []treeNode{\n", varName); err != nil {
+		return err
+	}
+
+	for _, node := range d.tree {
+		if _, err := fmt.Fprintf(w, "\t{Pos: %d, Brighter: %d, Darker: %d, Similar: %d},\n", node.Pos, node.Brighter, node.Darker, node.Similar); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
@@ -0,0 +1,52 @@
+package fast
+
+import "testing"
+
+// brightPatch and darkPatch mirror the circle-pixel shapes isCornerN itself
+// would call a corner / non-corner for a center value of 100 and a
+// threshold of 20: twelve contiguous bright pixels, versus a uniform ring.
+func brightPatch() (int, [16]int) {
+	return 100, [16]int{200, 200, 200, 200, 200, 200, 200, 200, 200, 200, 200, 200, 50, 50, 50, 50}
+}
+
+func flatPatch() (int, [16]int) {
+	return 100, [16]int{100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100}
+}
+
+func TestCompileTrainsATreeThatAgreesWithIsCornerN(t *testing.T) {
+	const threshold = 20
+	const n = 9
+
+	brightP, brightCircle := brightPatch()
+	flatP, flatCircle := flatPatch()
+
+	patches := []LabeledPatch{
+		{P: brightP, CirclePixels: brightCircle, Threshold: threshold, IsCorner: isCornerN(brightP, brightCircle, threshold, n)},
+		{P: flatP, CirclePixels: flatCircle, Threshold: threshold, IsCorner: isCornerN(flatP, flatCircle, threshold, n)},
+	}
+
+	var d Detector
+	d.Compile(patches)
+
+	for _, patch := range patches {
+		want := isCornerN(patch.P, patch.CirclePixels, patch.Threshold, n)
+		got := d.isCornerTree(patch.P, patch.CirclePixels, patch.Threshold)
+		if got != want {
+			t.Fatalf("isCornerTree(%v) = %v, want %v (isCornerN)", patch, got, want)
+		}
+		if patch.IsCorner != want {
+			t.Fatalf("test patch mislabeled: IsCorner=%v, isCornerN=%v", patch.IsCorner, want)
+		}
+	}
+}
+
+func TestCompilePanicsWithoutPatches(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Compile to panic with no training data")
+		}
+	}()
+
+	var d Detector
+	d.Compile(nil)
+}